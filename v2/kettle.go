@@ -0,0 +1,339 @@
+package kettle
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redsync/redsync/v4"
+	goredis "github.com/go-redsync/redsync/v4/redis/goredis/v8"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type DistLocker interface {
+	Lock() error
+	Unlock() bool
+
+	// Extend refreshes the lock's TTL. Long-running master work should
+	// call it periodically instead of relying purely on the tick cadence
+	// matching the lock's expiry.
+	Extend() error
+}
+
+// redsyncLocker adapts a *redsync.Mutex, whose Unlock and Extend both
+// return (bool, error), to the single-return-value DistLocker interface.
+type redsyncLocker struct{ m *redsync.Mutex }
+
+func (r redsyncLocker) Lock() error { return r.m.Lock() }
+
+func (r redsyncLocker) Unlock() bool {
+	ok, _ := r.m.Unlock()
+	return ok
+}
+
+func (r redsyncLocker) Extend() error {
+	_, err := r.m.Extend()
+	return err
+}
+
+// Client is the Redis client abstraction Kettle depends on. It is satisfied
+// by *redis.Client, *redis.ClusterClient and *redis.Ring from go-redis, so
+// callers can inject any pre-built client (sentinel, cluster, TLS, custom
+// dialer, etc.) via WithRedisClient instead of going through REDIS_HOST.
+type Client = redis.UniversalClient
+
+type KettleOption interface {
+	Apply(*Kettle)
+}
+
+type withName string
+
+func (w withName) Apply(o *Kettle)   { o.name = string(w) }
+func WithName(v string) KettleOption { return withName(v) }
+
+type withVerbose bool
+
+func (w withVerbose) Apply(o *Kettle) { o.verbose = bool(w) }
+func WithVerbose(v bool) KettleOption { return withVerbose(v) }
+
+type withDistLocker struct{ dl DistLocker }
+
+func (w withDistLocker) Apply(o *Kettle)       { o.lock = w.dl }
+func WithDistLocker(v DistLocker) KettleOption { return withDistLocker{v} }
+
+type withRedisClient struct{ c Client }
+
+func (w withRedisClient) Apply(o *Kettle)   { o.client = w.c }
+func WithRedisClient(v Client) KettleOption { return withRedisClient{v} }
+
+type withRedisConfig struct{ cfg RedisConfig }
+
+func (w withRedisConfig) Apply(o *Kettle)        { o.redisConfig = &w.cfg }
+func WithRedisConfig(v RedisConfig) KettleOption { return withRedisConfig{v} }
+
+type withTickTime int64
+
+func (w withTickTime) Apply(o *Kettle)  { o.tickTime = int64(w) }
+func WithTickTime(v int64) KettleOption { return withTickTime(v) }
+
+type Kettle struct {
+	name        string
+	verbose     bool
+	logger      Logger
+	client      Client
+	redisConfig *RedisConfig // set via WithRedisConfig, used when client is nil
+	lock        DistLocker
+	master      int32 // 1 if we are master, otherwise, 0
+	hostname    string
+	startInput  *StartInput // copy of StartInput
+	masterQuit  chan error  // signal master set to quit
+	masterDone  chan error  // master termination done
+	tickTime    int64
+}
+
+func (s Kettle) Name() string    { return s.name }
+func (s Kettle) IsVerbose() bool { return s.verbose }
+
+// Client returns the underlying Redis client. Prefer this over Pool.
+func (s Kettle) Client() Client { return s.client }
+
+// Pool returns the underlying Redis client.
+//
+// Deprecated: use Client instead. Kettle no longer pools redigo
+// connections; this is kept only so v1 callers have a mechanical rename.
+func (s Kettle) Pool() Client { return s.client }
+
+// Extend refreshes the underlying DistLocker's TTL. A Master,
+// OnBecomeMaster, or OnResignMaster callback that can run longer than
+// tickTime should call this periodically itself, rather than relying on
+// the tick cadence alone to keep the lock alive for the duration of the
+// call.
+func (s Kettle) Extend() error {
+	if s.lock == nil {
+		return errors.Errorf("[%v] lock not configured", s.name)
+	}
+
+	return s.lock.Extend()
+}
+
+func (s Kettle) info(v ...interface{}) {
+	if !s.verbose {
+		return
+	}
+
+	s.logger.Info(fmt.Sprintln(v...))
+}
+
+func (s Kettle) infof(format string, v ...interface{}) {
+	if !s.verbose {
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf(format, v...))
+}
+
+func (s Kettle) error(v ...interface{}) {
+	if !s.verbose {
+		return
+	}
+
+	s.logger.Error(fmt.Sprintln(v...))
+}
+
+func (s Kettle) errorf(format string, v ...interface{}) {
+	if !s.verbose {
+		return
+	}
+
+	s.logger.Error(fmt.Sprintf(format, v...))
+}
+
+// fatal and fatalf report a library-fatal condition without killing the
+// host process; the caller decides what to do with the returned error.
+func (s Kettle) fatal(v ...interface{}) error {
+	s.error(v...)
+	return errors.New(fmt.Sprint(v...))
+}
+
+func (s Kettle) fatalf(format string, v ...interface{}) error {
+	s.errorf(format, v...)
+	return errors.Errorf(format, v...)
+}
+
+func (s Kettle) isMaster() bool {
+	if atomic.LoadInt32(&s.master) == 1 {
+		return true
+	} else {
+		return false
+	}
+}
+
+func (s *Kettle) setMaster() {
+	if err := s.lock.Lock(); err != nil {
+		s.infof("[%v] %v set to worker", s.name, s.hostname)
+		if atomic.SwapInt32(&s.master, 0) == 1 {
+			s.onLeaderChanged(false)
+		}
+
+		return
+	}
+
+	s.infof("[%v] %v set to master", s.name, s.hostname)
+	if atomic.SwapInt32(&s.master, 1) == 0 {
+		s.onLeaderChanged(true)
+	}
+}
+
+// onLeaderChanged fires exactly once per master/worker transition: it
+// notifies LeaderChanged (non-blocking, so a slow consumer never stalls
+// the master loop) and invokes OnBecomeMaster/OnResignMaster.
+func (s *Kettle) onLeaderChanged(isMaster bool) {
+	if s.startInput.LeaderChanged != nil {
+		select {
+		case s.startInput.LeaderChanged <- isMaster:
+		default:
+		}
+	}
+
+	if isMaster {
+		if s.startInput.OnBecomeMaster != nil {
+			if err := s.startInput.OnBecomeMaster(s.startInput.MasterCtx); err != nil {
+				s.errorf("[%v] OnBecomeMaster: %v", s.name, err)
+			}
+		}
+
+		return
+	}
+
+	if s.startInput.OnResignMaster != nil {
+		if err := s.startInput.OnResignMaster(s.startInput.MasterCtx); err != nil {
+			s.errorf("[%v] OnResignMaster: %v", s.name, err)
+		}
+	}
+}
+
+func (s *Kettle) doMaster() {
+	masterTicker := time.NewTicker(time.Second * time.Duration(s.tickTime))
+
+	work := func() {
+		// Attempt to be master here.
+		s.setMaster()
+
+		// Only if we are master.
+		if s.isMaster() {
+			if s.startInput.Master != nil {
+				s.startInput.Master(s.startInput.MasterCtx)
+			}
+		}
+	}
+
+	work() // first invoke before tick
+
+	go func() {
+		for {
+			select {
+			case <-masterTicker.C:
+				work() // succeeding ticks
+			case <-s.masterQuit:
+				s.masterDone <- nil
+				return
+			}
+		}
+	}()
+}
+
+type StartInput struct {
+	Master    func(ctx interface{}) error // function to call every time we are master
+	MasterCtx interface{}                 // callback function parameter
+	Quit      chan error                  // signal for us to terminate
+	Done      chan error                  // report that we are done
+
+	// OnBecomeMaster, if set, is called once when this instance transitions
+	// from worker to master, before the first Master invocation for that
+	// term. Use it for one-shot initialization (open files, start a gRPC
+	// server, register with service discovery).
+	OnBecomeMaster func(ctx interface{}) error
+
+	// OnResignMaster, if set, is called once when this instance transitions
+	// from master back to worker, e.g. to release resources acquired in
+	// OnBecomeMaster.
+	OnResignMaster func(ctx interface{}) error
+
+	// LeaderChanged, if set, receives true/false every time the master
+	// flag transitions. Sends are non-blocking, so a consumer that falls
+	// behind misses intermediate transitions but never stalls the master
+	// loop.
+	LeaderChanged chan bool
+}
+
+func (s *Kettle) Start(in *StartInput) error {
+	if in == nil {
+		return errors.Errorf("input cannot be nil")
+	}
+
+	s.startInput = in
+	hostname, _ := os.Hostname()
+	hostname = hostname + fmt.Sprintf("__%s", uuid.New())
+	s.hostname = hostname
+
+	s.masterQuit = make(chan error, 1)
+	s.masterDone = make(chan error, 1)
+
+	go func() {
+		<-in.Quit
+		s.infof("[%v] requested to terminate", s.name)
+
+		// Attempt to gracefully terminate master.
+		s.masterQuit <- nil
+		<-s.masterDone
+
+		s.infof("[%v] terminate complete", s.name)
+		in.Done <- nil
+	}()
+
+	go s.doMaster()
+
+	return nil
+}
+
+func New(opts ...KettleOption) (*Kettle, error) {
+	s := &Kettle{
+		name:     "kettle",
+		tickTime: 30,
+		logger:   defaultLogger{},
+	}
+
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+
+	if s.lock == nil {
+		if s.client == nil {
+			var c Client
+			var err error
+			if s.redisConfig != nil {
+				c, err = NewRedisClientFromConfig(*s.redisConfig)
+			} else {
+				c, err = NewRedisClient()
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			s.client = c
+		}
+
+		pool := goredis.NewPool(s.client)
+		rs := redsync.New(pool)
+		s.lock = redsyncLocker{rs.NewMutex(
+			fmt.Sprintf("%v-distlocker", s.name),
+			redsync.WithExpiry(time.Second*time.Duration(s.tickTime)),
+		)}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,74 @@
+package kettle
+
+import (
+	"errors"
+	"testing"
+)
+
+type toggleLocker struct{ locked bool }
+
+func (l *toggleLocker) Lock() error {
+	if l.locked {
+		return nil
+	}
+
+	return errors.New("not master")
+}
+
+func (l *toggleLocker) Unlock() bool  { return true }
+func (l *toggleLocker) Extend() error { return nil }
+
+func TestSetMasterFiresHooksOncePerTransition(t *testing.T) {
+	lock := &toggleLocker{locked: true}
+
+	var becomes, resigns int
+	changed := make(chan bool, 10)
+
+	k := &Kettle{
+		lock: lock,
+		startInput: &StartInput{
+			OnBecomeMaster: func(ctx interface{}) error { becomes++; return nil },
+			OnResignMaster: func(ctx interface{}) error { resigns++; return nil },
+			LeaderChanged:  changed,
+		},
+	}
+
+	k.setMaster() // worker -> master: fires once
+	k.setMaster() // still master: no transition
+
+	lock.locked = false
+	k.setMaster() // master -> worker: fires once
+	k.setMaster() // still worker: no transition
+
+	if becomes != 1 {
+		t.Errorf("OnBecomeMaster called %d times, want 1", becomes)
+	}
+
+	if resigns != 1 {
+		t.Errorf("OnResignMaster called %d times, want 1", resigns)
+	}
+
+	close(changed)
+	var got []bool
+	for v := range changed {
+		got = append(got, v)
+	}
+
+	if len(got) != 2 || got[0] != true || got[1] != false {
+		t.Errorf("LeaderChanged = %v, want [true false]", got)
+	}
+}
+
+func TestSetMasterLeaderChangedNonBlocking(t *testing.T) {
+	lock := &toggleLocker{locked: true}
+
+	k := &Kettle{
+		lock: lock,
+		startInput: &StartInput{
+			LeaderChanged: make(chan bool), // unbuffered, nobody reading
+		},
+	}
+
+	// Must not deadlock even though LeaderChanged has no reader.
+	k.setMaster()
+}
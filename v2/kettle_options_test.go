@@ -0,0 +1,72 @@
+package kettle
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type fakeLocker struct{}
+
+func (fakeLocker) Lock() error   { return nil }
+func (fakeLocker) Unlock() bool  { return true }
+func (fakeLocker) Extend() error { return nil }
+
+func TestKettleOptionsApply(t *testing.T) {
+	dl := fakeLocker{}
+	k := &Kettle{}
+
+	opts := []KettleOption{
+		WithName("test"),
+		WithVerbose(true),
+		WithDistLocker(dl),
+		WithTickTime(5),
+	}
+
+	for _, opt := range opts {
+		opt.Apply(k)
+	}
+
+	if k.name != "test" {
+		t.Errorf("name = %q, want %q", k.name, "test")
+	}
+
+	if !k.verbose {
+		t.Error("verbose = false, want true")
+	}
+
+	if k.lock != dl {
+		t.Error("lock not set by WithDistLocker")
+	}
+
+	if k.tickTime != 5 {
+		t.Errorf("tickTime = %d, want 5", k.tickTime)
+	}
+}
+
+func TestWithRedisClientSetsClient(t *testing.T) {
+	// redis.NewClient dials lazily, so this never touches the network.
+	fakeClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+
+	k := &Kettle{}
+	WithRedisClient(fakeClient).Apply(k)
+
+	if k.client != fakeClient {
+		t.Error("client not set by WithRedisClient")
+	}
+}
+
+func TestWithRedisConfigSetsRedisConfig(t *testing.T) {
+	cfg := RedisConfig{Addr: "127.0.0.1:6379", DB: 2}
+
+	k := &Kettle{}
+	WithRedisConfig(cfg).Apply(k)
+
+	if k.redisConfig == nil {
+		t.Fatal("redisConfig not set by WithRedisConfig")
+	}
+
+	if k.redisConfig.Addr != cfg.Addr || k.redisConfig.DB != cfg.DB {
+		t.Errorf("redisConfig = %+v, want %+v", *k.redisConfig, cfg)
+	}
+}
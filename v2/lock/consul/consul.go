@@ -0,0 +1,135 @@
+// Package consul is a reference kettle.DistLocker implementation for users
+// who want leader election without a Redis dependency. It uses a Consul
+// session plus KV lock, following the same acquire/resign/extend shape as
+// the built-in redsync-backed locker.
+package consul
+
+import (
+	"time"
+
+	kettle "github.com/flowerinthenight/share/v2"
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+)
+
+var _ kettle.DistLocker = (*Locker)(nil)
+
+// Locker is a kettle.DistLocker backed by a Consul session + KV lock.
+type Locker struct {
+	client *consulapi.Client
+	key    string
+	ttl    time.Duration
+
+	sessionID string
+	lock      *consulapi.Lock
+	stop      chan struct{}
+}
+
+// New creates a Locker that contends for key using client, with a session
+// TTL of ttl.
+func New(client *consulapi.Client, key string, ttl time.Duration) *Locker {
+	return &Locker{client: client, key: key, ttl: ttl}
+}
+
+func (l *Locker) Lock() error {
+	// Kettle calls Lock() on every tick whether or not it's currently
+	// master, so re-entering here must renew the existing session instead
+	// of creating a new one and contending against ourselves for the KV
+	// lock.
+	return lockOrExtend(l.lock != nil, l.Extend, l.campaign, l.reset)
+}
+
+func (l *Locker) campaign() error {
+	sessionID, _, err := l.client.Session().Create(&consulapi.SessionEntry{
+		TTL:      l.ttl.String(),
+		Behavior: consulapi.SessionBehaviorRelease,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	// TryLock semantics: make a single acquire attempt, waiting at most
+	// LockWaitTime, instead of blocking until the lock frees up.
+	lock, err := l.client.LockOpts(&consulapi.LockOptions{
+		Key:          l.key,
+		Session:      sessionID,
+		LockTryOnce:  true,
+		LockWaitTime: time.Second,
+	})
+	if err != nil {
+		l.client.Session().Destroy(sessionID, nil)
+		return err
+	}
+
+	leaderCh, err := lock.Lock(nil)
+	if err != nil {
+		l.client.Session().Destroy(sessionID, nil)
+		return err
+	}
+
+	if leaderCh == nil {
+		l.client.Session().Destroy(sessionID, nil)
+		return errors.Errorf("consul: lock %q held by another instance", l.key)
+	}
+
+	l.sessionID = sessionID
+	l.lock = lock
+	return nil
+}
+
+func (l *Locker) reset() {
+	l.lock = nil
+	l.sessionID = ""
+}
+
+// lockOrExtend is the acquire/extend/re-campaign decision at the heart of
+// Lock: if we're already holding the lock, renew it in place; if that
+// renewal fails (TTL lapsed, session invalidated, etc), reset drops our
+// local state so the very next call campaigns from scratch instead of
+// retrying the same dead session forever. It's factored out of Lock so
+// this state machine is testable without a live Consul agent.
+func lockOrExtend(held bool, extend, campaign func() error, reset func()) error {
+	if !held {
+		return campaign()
+	}
+
+	if err := extend(); err != nil {
+		reset()
+		return err
+	}
+
+	return nil
+}
+
+func (l *Locker) Unlock() bool {
+	if l.lock == nil {
+		return false
+	}
+
+	err := l.lock.Unlock()
+	l.client.Session().Destroy(l.sessionID, nil)
+	l.lock = nil
+	l.sessionID = ""
+	return err == nil
+}
+
+// Extend renews the Consul session so a long-running master callback
+// doesn't lose the lock purely because it outlives the TTL.
+func (l *Locker) Extend() error {
+	if l.sessionID == "" {
+		return errors.Errorf("consul: lock not held")
+	}
+
+	entry, _, err := l.client.Session().Renew(l.sessionID, nil)
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		// Renew reports an unknown (expired or destroyed) session as a
+		// nil entry with no error.
+		return errors.Errorf("consul: session %q no longer exists", l.sessionID)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package etcd is a reference kettle.DistLocker implementation for users
+// who want leader election without a Redis dependency. It campaigns for a
+// key using an etcd session, following the same acquire/resign/extend
+// shape as the built-in redsync-backed locker.
+package etcd
+
+import (
+	"context"
+	"time"
+
+	kettle "github.com/flowerinthenight/share/v2"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+var _ kettle.DistLocker = (*Locker)(nil)
+
+// Locker is a kettle.DistLocker backed by an etcd session + campaign.
+type Locker struct {
+	client *clientv3.Client
+	key    string
+	ttl    int // session TTL, in seconds
+
+	session *concurrency.Session
+	mutex   *concurrency.Mutex
+}
+
+// New creates a Locker that campaigns for key using client, with a session
+// TTL of ttlSeconds. A new session is created the first time Lock is
+// called, or again after Lock detects the held session's lease has died;
+// otherwise Lock reuses the existing session.
+func New(client *clientv3.Client, key string, ttlSeconds int) *Locker {
+	return &Locker{client: client, key: key, ttl: ttlSeconds}
+}
+
+func (l *Locker) Lock() error {
+	// Kettle calls Lock() on every tick whether or not it's currently
+	// master, so re-entering here must keep the existing session/lease
+	// alive instead of campaigning as a brand new, lower-priority
+	// contender against ourselves.
+	return lockOrExtend(l.session != nil, l.Extend, l.campaign, l.reset)
+}
+
+func (l *Locker) campaign() error {
+	session, err := concurrency.NewSession(l.client, concurrency.WithTTL(l.ttl))
+	if err != nil {
+		return err
+	}
+
+	mutex := concurrency.NewMutex(session, "/"+l.key)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(l.ttl)*time.Second)
+	defer cancel()
+
+	if err := mutex.TryLock(ctx); err != nil {
+		session.Close()
+		return err
+	}
+
+	l.session = session
+	l.mutex = mutex
+	return nil
+}
+
+func (l *Locker) reset() {
+	l.session = nil
+	l.mutex = nil
+}
+
+// lockOrExtend is the acquire/extend/re-campaign decision at the heart of
+// Lock: if we're already holding the lock, refresh it in place; if that
+// refresh fails (lease revoked, expired, etc), reset drops our local
+// state so the very next call campaigns from scratch instead of retrying
+// the same dead lease forever. It's factored out of Lock so this state
+// machine is testable without a live etcd server.
+func lockOrExtend(held bool, extend, campaign func() error, reset func()) error {
+	if !held {
+		return campaign()
+	}
+
+	if err := extend(); err != nil {
+		reset()
+		return err
+	}
+
+	return nil
+}
+
+func (l *Locker) Unlock() bool {
+	if l.mutex == nil {
+		return false
+	}
+
+	err := l.mutex.Unlock(context.Background())
+	l.session.Close()
+	l.mutex = nil
+	l.session = nil
+	return err == nil
+}
+
+// Extend refreshes the etcd session's lease so a long-running master
+// callback doesn't lose the lock purely because it outlives the TTL.
+func (l *Locker) Extend() error {
+	if l.session == nil {
+		return errors.Errorf("etcd: lock not held")
+	}
+
+	_, err := l.session.Client().KeepAliveOnce(context.Background(), l.session.Lease())
+	return err
+}
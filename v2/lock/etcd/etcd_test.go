@@ -0,0 +1,64 @@
+package etcd
+
+import (
+	"errors"
+	"testing"
+)
+
+// These tests exercise lockOrExtend, the acquire/extend/re-campaign
+// decision behind Lock, in isolation from the etcd client: NewSession and
+// NewMutex are free functions over a concrete *clientv3.Client, so there's
+// no live server to fake here, but the state machine itself is plain Go
+// and fully testable with fake extend/campaign/reset callbacks.
+
+func TestLockOrExtendNotHeldCampaigns(t *testing.T) {
+	var campaigned bool
+
+	err := lockOrExtend(false,
+		func() error { t.Fatal("extend should not be called when not held"); return nil },
+		func() error { campaigned = true; return nil },
+		func() { t.Fatal("reset should not be called when not held") },
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !campaigned {
+		t.Fatal("campaign was not called")
+	}
+}
+
+func TestLockOrExtendHeldExtendsWithoutRecampaigning(t *testing.T) {
+	var extended bool
+
+	err := lockOrExtend(true,
+		func() error { extended = true; return nil },
+		func() error { t.Fatal("campaign should not be called while held"); return nil },
+		func() { t.Fatal("reset should not be called on a successful extend") },
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !extended {
+		t.Fatal("extend was not called")
+	}
+}
+
+func TestLockOrExtendHeldExtendFailureResetsForRecampaign(t *testing.T) {
+	wantErr := errors.New("lease expired")
+	var reset bool
+
+	err := lockOrExtend(true,
+		func() error { return wantErr },
+		func() error { t.Fatal("campaign should not be called by the same Lock call"); return nil },
+		func() { reset = true },
+	)
+	if err != wantErr {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+
+	if !reset {
+		t.Fatal("reset was not called after a failed extend, so the next Lock would retry the same dead lease forever")
+	}
+}
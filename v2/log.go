@@ -0,0 +1,79 @@
+package kettle
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Logger is the structured logging hook Kettle calls into. kv is an
+// alternating list of key/value pairs, the convention expected by zap's
+// SugaredLogger, zerolog, and slog adapters alike.
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+type withLogger struct{ l Logger }
+
+func (w withLogger) Apply(o *Kettle)   { o.logger = w.l }
+func WithLogger(v Logger) KettleOption { return withLogger{v} }
+
+// isTTY reports whether stdout is a terminal. It's checked once at package
+// init instead of per-call since it can't change over the life of the
+// process.
+var isTTY = func() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}()
+
+// colorize wraps s in the given ANSI color code, unless stdout isn't a
+// terminal — piping into a log aggregator should never see raw escapes.
+func colorize(code, s string) string {
+	if !isTTY {
+		return s
+	}
+
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", code, s)
+}
+
+// defaultLogger is used when no Logger is supplied via WithLogger. It
+// preserves Kettle's historical colored stdout behavior.
+type defaultLogger struct{}
+
+func (defaultLogger) Debug(msg string, kv ...interface{}) {
+	log.Printf("%s %s", colorize("37", "[debug]"), format(msg, kv))
+}
+
+func (defaultLogger) Info(msg string, kv ...interface{}) {
+	log.Printf("%s %s", colorize("32", "[info]"), format(msg, kv))
+}
+
+func (defaultLogger) Error(msg string, kv ...interface{}) {
+	log.Printf("%s %s", colorize("31", "[error]"), format(msg, kv))
+}
+
+func format(msg string, kv []interface{}) string {
+	if len(kv) == 0 {
+		return msg
+	}
+
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(kv); i += 2 {
+		var v interface{} = "MISSING"
+		if i+1 < len(kv) {
+			v = kv[i+1]
+		}
+
+		fmt.Fprintf(&b, " %v=%v", kv[i], v)
+	}
+
+	return b.String()
+}
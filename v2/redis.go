@@ -1,43 +1,182 @@
 package kettle
 
 import (
+	"crypto/tls"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/gomodule/redigo/redis"
+	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
 )
 
-func NewRedisPool() (*redis.Pool, error) {
-	addr := os.Getenv("REDIS_HOST")
-	if addr == "" {
-		return nil, errors.Errorf("REDIS_HOST env variable must be set (e.g host:port, redis://password@host:port)")
+// RedisConfig describes how to reach a Redis deployment, be it a single
+// standalone instance, a Sentinel-monitored master/replica set, or a
+// Cluster. Exactly one of the three topologies should be configured;
+// NewRedisClientFromConfig picks the right go-redis constructor based on
+// which fields are set, preferring Cluster, then Sentinel, then standalone.
+type RedisConfig struct {
+	// Addr is the standalone host:port to dial.
+	Addr string
+
+	// SentinelMasterName and SentinelAddrs configure a Sentinel-monitored
+	// deployment. SentinelPassword authenticates against the sentinels
+	// themselves, separately from Password, which authenticates against
+	// the resolved master/replicas.
+	SentinelMasterName string
+	SentinelAddrs      []string
+	SentinelPassword   string
+
+	// ClusterAddrs configures a Cluster deployment via its seed nodes.
+	ClusterAddrs []string
+
+	Username string
+	Password string
+	DB       int
+
+	TLS bool
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// NewRedisClientFromConfig builds a Client for the topology described by
+// cfg: Cluster if ClusterAddrs is set, Sentinel if SentinelAddrs is set,
+// otherwise a standalone client dialing Addr.
+func NewRedisClientFromConfig(cfg RedisConfig) (Client, error) {
+	var tlsConfig *tls.Config
+	if cfg.TLS {
+		tlsConfig = &tls.Config{}
+	}
+
+	switch {
+	case len(cfg.ClusterAddrs) > 0:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.ClusterAddrs,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			TLSConfig:    tlsConfig,
+		}), nil
+	case len(cfg.SentinelAddrs) > 0:
+		if cfg.SentinelMasterName == "" {
+			return nil, errors.Errorf("SentinelMasterName must be set when SentinelAddrs is used")
+		}
+
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.SentinelMasterName,
+			SentinelAddrs:    cfg.SentinelAddrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			ReadTimeout:      cfg.ReadTimeout,
+			WriteTimeout:     cfg.WriteTimeout,
+			TLSConfig:        tlsConfig,
+		}), nil
+	case cfg.Addr != "":
+		return redis.NewClient(&redis.Options{
+			Addr:         cfg.Addr,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			TLSConfig:    tlsConfig,
+		}), nil
+	default:
+		return nil, errors.Errorf("one of Addr, SentinelAddrs or ClusterAddrs must be set")
+	}
+}
+
+// NewRedisClient builds a Client from the environment:
+//
+//	REDIS_HOST              standalone host:port
+//	REDIS_SENTINEL_MASTER   sentinel master name
+//	REDIS_SENTINEL_ADDRS    comma-separated sentinel host:port list
+//	REDIS_CLUSTER_ADDRS     comma-separated cluster seed host:port list
+//	REDIS_USERNAME          Redis 6 ACL username
+//	REDIS_PASSWORD          password for the master/replicas
+//	REDIS_SENTINEL_PASSWORD password for the sentinels themselves
+//	REDIS_DB                logical DB index (standalone/sentinel only)
+//	REDIS_TLS               "true" to dial with TLS
+//	REDIS_READ_TIMEOUT      read timeout, in seconds
+//	REDIS_WRITE_TIMEOUT     write timeout, in seconds
+//	REDIS_TIMEOUT_SECONDS   legacy alias for REDIS_READ_TIMEOUT and
+//	                        REDIS_WRITE_TIMEOUT, kept for v1 compatibility
+//
+// It is the default used by New when no DistLocker, Client or RedisConfig
+// is supplied.
+func NewRedisClient() (Client, error) {
+	cfg := RedisConfig{
+		Addr:               os.Getenv("REDIS_HOST"),
+		SentinelMasterName: os.Getenv("REDIS_SENTINEL_MASTER"),
+		SentinelAddrs:      splitAddrs(os.Getenv("REDIS_SENTINEL_ADDRS")),
+		SentinelPassword:   os.Getenv("REDIS_SENTINEL_PASSWORD"),
+		ClusterAddrs:       splitAddrs(os.Getenv("REDIS_CLUSTER_ADDRS")),
+		Username:           os.Getenv("REDIS_USERNAME"),
+		Password:           os.Getenv("REDIS_PASSWORD"),
+		TLS:                os.Getenv("REDIS_TLS") == "true",
+	}
+
+	if len(cfg.SentinelAddrs) == 0 && len(cfg.ClusterAddrs) == 0 && cfg.Addr == "" {
+		return nil, errors.Errorf("one of REDIS_HOST, REDIS_SENTINEL_ADDRS or REDIS_CLUSTER_ADDRS must be set")
+	}
+
+	if db := os.Getenv("REDIS_DB"); db != "" {
+		v, err := strconv.Atoi(db)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.DB = v
 	}
 
-	var dialOpts []redis.DialOption
-	password := os.Getenv("REDIS_PASSWORD")
-	if password != "" {
-		dialOpts = append(dialOpts, redis.DialPassword(password))
+	if tm := os.Getenv("REDIS_TIMEOUT_SECONDS"); tm != "" {
+		v, err := strconv.Atoi(tm)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.ReadTimeout = time.Duration(v) * time.Second
+		cfg.WriteTimeout = time.Duration(v) * time.Second
 	}
 
-	tm := os.Getenv("REDIS_TIMEOUT_SECONDS")
-	if tm != "" {
-		tmsec, err := strconv.Atoi(tm)
+	if tm := os.Getenv("REDIS_READ_TIMEOUT"); tm != "" {
+		v, err := strconv.Atoi(tm)
 		if err != nil {
 			return nil, err
-		} else {
-			dialOpts = append(dialOpts, redis.DialConnectTimeout(time.Duration(tmsec)*time.Second))
 		}
+
+		cfg.ReadTimeout = time.Duration(v) * time.Second
 	}
 
-	rp := &redis.Pool{
-		MaxIdle:     3,
-		MaxActive:   4,
-		Wait:        true,
-		IdleTimeout: 240 * time.Second,
-		Dial:        func() (redis.Conn, error) { return redis.Dial("tcp", addr, dialOpts...) },
+	if tm := os.Getenv("REDIS_WRITE_TIMEOUT"); tm != "" {
+		v, err := strconv.Atoi(tm)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.WriteTimeout = time.Duration(v) * time.Second
+	}
+
+	return NewRedisClientFromConfig(cfg)
+}
+
+func splitAddrs(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	var out []string
+	for _, a := range strings.Split(v, ",") {
+		a = strings.TrimSpace(a)
+		if a != "" {
+			out = append(out, a)
+		}
 	}
 
-	return rp, nil
+	return out
 }
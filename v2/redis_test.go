@@ -0,0 +1,60 @@
+package kettle
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSplitAddrs(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"a:1", []string{"a:1"}},
+		{"a:1,b:2", []string{"a:1", "b:2"}},
+		{" a:1 , b:2 ", []string{"a:1", "b:2"}},
+		{"a:1,,b:2", []string{"a:1", "b:2"}},
+	}
+
+	for _, c := range cases {
+		got := splitAddrs(c.in)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("splitAddrs(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestNewRedisClientFromConfigTopology(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  RedisConfig
+		want string
+	}{
+		{"cluster", RedisConfig{ClusterAddrs: []string{"h1:1", "h2:2"}}, "*redis.ClusterClient"},
+		{"sentinel", RedisConfig{SentinelMasterName: "m", SentinelAddrs: []string{"s1:1"}}, "*redis.Client"},
+		{"standalone", RedisConfig{Addr: "h:1"}, "*redis.Client"},
+	}
+
+	for _, c := range cases {
+		got, err := NewRedisClientFromConfig(c.cfg)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+
+		if tn := fmt.Sprintf("%T", got); tn != c.want {
+			t.Errorf("%s: type = %s, want %s", c.name, tn, c.want)
+		}
+	}
+}
+
+func TestNewRedisClientFromConfigErrors(t *testing.T) {
+	if _, err := NewRedisClientFromConfig(RedisConfig{}); err == nil {
+		t.Error("expected error for an empty config")
+	}
+
+	if _, err := NewRedisClientFromConfig(RedisConfig{SentinelAddrs: []string{"s1:1"}}); err == nil {
+		t.Error("expected error when SentinelMasterName is missing")
+	}
+}
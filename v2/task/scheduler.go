@@ -0,0 +1,252 @@
+// Package task turns a Kettle leader election into a distributed cron: jobs
+// registered on a Scheduler run exactly once across the fleet, on whichever
+// instance currently holds the Kettle lock.
+package task
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	kettle "github.com/flowerinthenight/share/v2"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+// JobFunc is a unit of work dispatched by a Scheduler while its Kettle is
+// master. The context is cancelled if leadership is lost mid-run.
+type JobFunc func(ctx context.Context) error
+
+// Status is a snapshot of a registered job's run history.
+type Status struct {
+	Name    string
+	Running bool
+	LastRun time.Time
+	LastErr error
+	NextRun time.Time // zero for a RunOnce job
+}
+
+type job struct {
+	name     string
+	fn       JobFunc
+	schedule cron.Schedule // nil for RunOnce
+	window   time.Duration // idempotency-claim TTL
+	once     bool
+
+	nextRun time.Time
+	lastRun time.Time
+	lastErr error
+	cancel  context.CancelFunc
+}
+
+// Scheduler dispatches registered jobs on every tick that its Kettle is
+// master, and cancels in-flight jobs as soon as leadership is lost. Wire it
+// into a Kettle via StartInput:
+//
+//	sched := task.NewScheduler(k)
+//	sched.Every("sync", time.Minute, syncFn)
+//	k.Start(&kettle.StartInput{
+//		Master:         sched.Dispatch,
+//		OnResignMaster: sched.Pause,
+//		Quit:           quit,
+//		Done:           done,
+//	})
+type Scheduler struct {
+	k *kettle.Kettle
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// NewScheduler creates a Scheduler whose jobs are claimed (for idempotency
+// across fail-over) using k's Redis client, when one is available.
+func NewScheduler(k *kettle.Kettle) *Scheduler {
+	return &Scheduler{k: k, jobs: make(map[string]*job)}
+}
+
+// Every registers fn to run every interval while master, on epoch-aligned
+// slots (see fixedSchedule.Next) so its Redis idempotency claim still
+// works after a fail-over to an instance that registered the job later.
+func (s *Scheduler) Every(name string, interval time.Duration, fn JobFunc) error {
+	return s.add(&job{
+		name:     name,
+		fn:       fn,
+		schedule: fixedSchedule{interval},
+		window:   interval,
+	})
+}
+
+// Cron registers fn to run on the standard 5-field cron spec while master.
+func (s *Scheduler) Cron(name, spec string, fn JobFunc) error {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return errors.Wrapf(err, "task: invalid cron spec for %q", name)
+	}
+
+	return s.add(&job{
+		name:     name,
+		fn:       fn,
+		schedule: sched,
+		window:   5 * time.Minute,
+	})
+}
+
+// RunOnce registers fn to run a single time across the fleet's lifetime.
+func (s *Scheduler) RunOnce(name string, fn JobFunc) error {
+	return s.add(&job{name: name, fn: fn, once: true})
+}
+
+func (s *Scheduler) add(j *job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[j.name]; ok {
+		return errors.Errorf("task: job %q already registered", j.name)
+	}
+
+	if j.schedule != nil {
+		j.nextRun = j.schedule.Next(time.Now())
+	}
+
+	s.jobs[j.name] = j
+	return nil
+}
+
+// Dispatch runs any jobs that are due. It is meant to be used directly as
+// StartInput.Master, so it is called on every tick while the Kettle is
+// master.
+func (s *Scheduler) Dispatch(ctx interface{}) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	due := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		if j.cancel != nil {
+			continue // still running a previous invocation
+		}
+
+		switch {
+		case j.once:
+			if j.lastRun.IsZero() {
+				due = append(due, j)
+			}
+		case !j.nextRun.After(now):
+			due = append(due, j)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, j := range due {
+		s.run(j)
+	}
+
+	return nil
+}
+
+// Pause cancels every in-flight job context. It is meant to be used
+// directly as StartInput.OnResignMaster, so a fail-over never leaves two
+// instances running the same job.
+func (s *Scheduler) Pause(ctx interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.cancel != nil {
+			j.cancel()
+			j.cancel = nil
+		}
+	}
+
+	return nil
+}
+
+// Status returns a snapshot of every registered job's run history.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		out = append(out, Status{
+			Name:    j.name,
+			Running: j.cancel != nil,
+			LastRun: j.lastRun,
+			LastErr: j.lastErr,
+			NextRun: j.nextRun,
+		})
+	}
+
+	return out
+}
+
+func (s *Scheduler) run(j *job) {
+	runID := "once"
+	if !j.once {
+		runID = strconv.FormatInt(j.nextRun.Unix(), 10)
+
+		s.mu.Lock()
+		j.nextRun = j.schedule.Next(time.Now())
+		s.mu.Unlock()
+	}
+
+	if !s.claim(j, runID) {
+		return
+	}
+
+	jctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	j.cancel = cancel
+	s.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := j.fn(jctx)
+
+		s.mu.Lock()
+		j.lastRun = time.Now()
+		j.lastErr = err
+		j.cancel = nil
+		s.mu.Unlock()
+	}()
+}
+
+// claim marks (name, runID) as taken in Redis so a fail-over mid-run can't
+// cause two instances to execute the same scheduled run. It fails open
+// (returns true) if there is no Redis client or the call itself errors, so
+// a Redis hiccup never blocks a job that's otherwise due.
+func (s *Scheduler) claim(j *job, runID string) bool {
+	c := s.k.Client()
+	if c == nil {
+		return true
+	}
+
+	key := fmt.Sprintf("kettle-task:%s:%s", j.name, runID)
+	ttl := j.window
+	if j.once {
+		ttl = 0 // never expires; a RunOnce job only ever runs once
+	}
+
+	ok, err := c.SetNX(context.Background(), key, s.k.Name(), ttl).Result()
+	if err != nil {
+		return true
+	}
+
+	return ok
+}
+
+// fixedSchedule adapts a fixed interval to the cron.Schedule interface so
+// Every and Cron jobs share the same due-time bookkeeping. Next rounds up
+// to the next multiple of d since the Unix epoch, rather than t.Add(d),
+// so every instance in the fleet computes the same scheduled slots (and
+// therefore the same claim runID) regardless of when each registered the
+// job — the same alignment a standard cron spec gives Cron jobs for free.
+type fixedSchedule struct{ d time.Duration }
+
+func (f fixedSchedule) Next(t time.Time) time.Time {
+	d := f.d.Nanoseconds()
+	next := (t.UnixNano()/d + 1) * d
+	return time.Unix(0, next)
+}
@@ -0,0 +1,128 @@
+package task
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kettle "github.com/flowerinthenight/share/v2"
+)
+
+func TestFixedScheduleAlignsToEpochSlots(t *testing.T) {
+	sched := fixedSchedule{d: time.Minute}
+
+	t1 := time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+	t2 := time.Date(2024, 1, 1, 0, 5, 10, 0, time.UTC)
+
+	got1 := sched.Next(t1)
+	got2 := sched.Next(t2)
+
+	want1 := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	want2 := time.Date(2024, 1, 1, 0, 6, 0, 0, time.UTC)
+
+	if !got1.Equal(want1) {
+		t.Errorf("Next(%v) = %v, want %v", t1, got1, want1)
+	}
+
+	if !got2.Equal(want2) {
+		t.Errorf("Next(%v) = %v, want %v", t2, got2, want2)
+	}
+}
+
+func TestDispatchSkipsJobStillRunning(t *testing.T) {
+	s := NewScheduler(&kettle.Kettle{})
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	err := s.Every("slow", time.Minute, func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		close(started)
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Every: %v", err)
+	}
+
+	// Force the job due and dispatch: it starts running in the background.
+	s.jobs["slow"].nextRun = time.Now().Add(-time.Second)
+	if err := s.Dispatch(nil); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	<-started
+
+	// fn is still blocked on release, but "now" has already moved past
+	// nextRun again, same as if the run had overrun its own interval.
+	// Dispatch must not spawn a second goroutine for the same job.
+	s.jobs["slow"].nextRun = time.Now().Add(-time.Second)
+	if err := s.Dispatch(nil); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	close(release)
+
+	deadline := time.After(time.Second)
+	for {
+		s.mu.Lock()
+		done := s.jobs["slow"].cancel == nil
+		s.mu.Unlock()
+		if done {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("job never finished")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("fn called %d times while a previous run was still in flight, want 1", got)
+	}
+}
+
+func TestDispatchRunsOnceJobOnlyOnce(t *testing.T) {
+	s := NewScheduler(&kettle.Kettle{})
+
+	var calls int32
+	err := s.RunOnce("init", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := s.Dispatch(nil); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		for _, st := range s.Status() {
+			_ = st // Status should not panic while jobs are mid-flight
+		}
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		s.mu.Lock()
+		ran := !s.jobs["init"].lastRun.IsZero()
+		s.mu.Unlock()
+		if ran {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("job never ran")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("fn called %d times, want 1", got)
+	}
+}